@@ -0,0 +1,222 @@
+// Package collector exposes statistics gathered by this module's /proc
+// parsers as prometheus.Collector implementations, so exporters such as
+// node_exporter can register them with a single call instead of wiring up
+// each metric by hand.
+package collector
+
+import (
+	"fmt"
+
+	"github.com/AvengerMoJo/procfs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace     = "node"
+	nfsdSubsystem = "nfsd"
+)
+
+// NFSdCollector collects metrics from /proc/net/rpc/nfsd via procfs.FS.
+type NFSdCollector struct {
+	fs procfs.FS
+
+	replyCache  *prometheus.Desc
+	fileHandles *prometheus.Desc
+	io          *prometheus.Desc
+	threads     *prometheus.Desc
+	readAhead   *prometheus.Desc
+	network     *prometheus.Desc
+	rpc         *prometheus.Desc
+	proc2       *prometheus.Desc
+	proc3       *prometheus.Desc
+	proc4       *prometheus.Desc
+	proc4ops    *prometheus.Desc
+}
+
+// NewNFSdCollector returns a new NFSdCollector reading from the given procfs.FS.
+func NewNFSdCollector(fs procfs.FS) *NFSdCollector {
+	return &NFSdCollector{
+		fs: fs,
+		replyCache: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "reply_cache_events_total"),
+			"Number of NFSd reply cache events by result.",
+			[]string{"result"}, nil,
+		),
+		fileHandles: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "file_handles_total"),
+			"Number of NFSd file handle events by type.",
+			[]string{"type"}, nil,
+		),
+		io: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "disk_bytes_total"),
+			"Number of bytes NFSd has read from or written to disk.",
+			[]string{"direction"}, nil,
+		),
+		threads: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "server_threads"),
+			"Number of NFSd server threads, and how often the thread pool was exhausted.",
+			[]string{"pool"}, nil,
+		),
+		readAhead: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "read_ahead_depth_ratio"),
+			"Histogram of NFSd read-ahead cache hits by fraction of the read-ahead depth at which the block was found.",
+			nil, nil,
+		),
+		network: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "network_events_total"),
+			"Number of NFSd network events by type.",
+			[]string{"protocol"}, nil,
+		),
+		rpc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "rpc_events_total"),
+			"Number of NFSd RPC events by result.",
+			[]string{"result"}, nil,
+		),
+		proc2: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "v2_procedures_total"),
+			"Number of NFSv2 server procedure calls by procedure.",
+			[]string{"procedure"}, nil,
+		),
+		proc3: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "v3_procedures_total"),
+			"Number of NFSv3 server procedure calls by procedure.",
+			[]string{"procedure"}, nil,
+		),
+		proc4: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "v4_procedures_total"),
+			"Number of NFSv4 server procedure calls by procedure.",
+			[]string{"procedure"}, nil,
+		),
+		proc4ops: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, nfsdSubsystem, "v4_operations_total"),
+			"Number of NFSv4 server operations by operation.",
+			[]string{"operation"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *NFSdCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.replyCache
+	ch <- c.fileHandles
+	ch <- c.io
+	ch <- c.threads
+	ch <- c.readAhead
+	ch <- c.network
+	ch <- c.rpc
+	ch <- c.proc2
+	ch <- c.proc3
+	ch <- c.proc4
+	ch <- c.proc4ops
+}
+
+// Collect implements prometheus.Collector.
+func (c *NFSdCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.fs.NewNFSdRPCStats()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.replyCache, err)
+		return
+	}
+
+	rc := stats.NFSdReplyCache
+	ch <- prometheus.MustNewConstMetric(c.replyCache, prometheus.CounterValue, float64(rc.Hits), "hit")
+	ch <- prometheus.MustNewConstMetric(c.replyCache, prometheus.CounterValue, float64(rc.Misses), "miss")
+	ch <- prometheus.MustNewConstMetric(c.replyCache, prometheus.CounterValue, float64(rc.NoCache), "nocache")
+
+	fh := stats.NFSdFileHandles
+	ch <- prometheus.MustNewConstMetric(c.fileHandles, prometheus.CounterValue, float64(fh.Stale), "stale")
+	ch <- prometheus.MustNewConstMetric(c.fileHandles, prometheus.CounterValue, float64(fh.TotalLookups), "lookup")
+	ch <- prometheus.MustNewConstMetric(c.fileHandles, prometheus.CounterValue, float64(fh.AnonLookups), "anon_lookup")
+	ch <- prometheus.MustNewConstMetric(c.fileHandles, prometheus.CounterValue, float64(fh.DirNoCache), "dir_no_cache")
+	ch <- prometheus.MustNewConstMetric(c.fileHandles, prometheus.CounterValue, float64(fh.NoDirNoCache), "no_dir_no_cache")
+
+	io := stats.NFSdInputOutput
+	ch <- prometheus.MustNewConstMetric(c.io, prometheus.CounterValue, float64(io.Read), "read")
+	ch <- prometheus.MustNewConstMetric(c.io, prometheus.CounterValue, float64(io.Write), "write")
+
+	th := stats.NFSdThreads
+	ch <- prometheus.MustNewConstMetric(c.threads, prometheus.GaugeValue, float64(th.Threads), "threads")
+	ch <- prometheus.MustNewConstMetric(c.threads, prometheus.CounterValue, float64(th.FullCnt), "exhausted")
+
+	ch <- c.readAheadHistogram(stats.NFSdReadAheadCache)
+
+	net := stats.NFSdNetwork
+	ch <- prometheus.MustNewConstMetric(c.network, prometheus.CounterValue, float64(net.NetCount), "total")
+	ch <- prometheus.MustNewConstMetric(c.network, prometheus.CounterValue, float64(net.UDPCount), "udp")
+	ch <- prometheus.MustNewConstMetric(c.network, prometheus.CounterValue, float64(net.TCPCount), "tcp")
+	ch <- prometheus.MustNewConstMetric(c.network, prometheus.CounterValue, float64(net.TCPConnect), "tcp_connect")
+
+	rpc := stats.NFSdRPC
+	ch <- prometheus.MustNewConstMetric(c.rpc, prometheus.CounterValue, float64(rpc.RPCCount), "total")
+	ch <- prometheus.MustNewConstMetric(c.rpc, prometheus.CounterValue, float64(rpc.BadCnt), "bad")
+	ch <- prometheus.MustNewConstMetric(c.rpc, prometheus.CounterValue, float64(rpc.BadFmt), "bad_format")
+	ch <- prometheus.MustNewConstMetric(c.rpc, prometheus.CounterValue, float64(rpc.BadAuth), "bad_auth")
+	ch <- prometheus.MustNewConstMetric(c.rpc, prometheus.CounterValue, float64(rpc.BadcInt), "bad_client")
+
+	v2 := stats.NFSdv2Stats
+	for proc, v := range map[string]uint64{
+		"null": v2.Null, "getattr": v2.GetAttr, "setattr": v2.SetAttr, "root": v2.Root,
+		"lookup": v2.Lookup, "readlink": v2.ReadLink, "read": v2.Read, "wrcache": v2.WrCache,
+		"write": v2.Write, "create": v2.Create, "remove": v2.Remove, "rename": v2.Rename,
+		"link": v2.Link, "symlink": v2.SymLink, "mkdir": v2.MkDir, "rmdir": v2.RmDir,
+		"readdir": v2.ReadDir, "fsstat": v2.FsStat,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.proc2, prometheus.CounterValue, float64(v), proc)
+	}
+
+	v3 := stats.NFSdv3Stats
+	for proc, v := range map[string]uint64{
+		"null": v3.Null, "getattr": v3.GetAttr, "setattr": v3.SetAttr, "lookup": v3.Lookup,
+		"access": v3.Access, "readlink": v3.ReadLink, "read": v3.Read, "write": v3.Write,
+		"create": v3.Create, "mkdir": v3.MkDir, "symlink": v3.SymLink, "mknod": v3.MkNod,
+		"remove": v3.Remove, "rmdir": v3.RmDir, "rename": v3.Rename, "link": v3.Link,
+		"readdir": v3.ReadDir, "readdirplus": v3.ReadDirPlus, "fsstat": v3.FsStat,
+		"fsinfo": v3.FsInfo, "pathconf": v3.PathConf, "commit": v3.Commit,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.proc3, prometheus.CounterValue, float64(v), proc)
+	}
+
+	v4 := stats.NFSdv4Stats
+	ch <- prometheus.MustNewConstMetric(c.proc4, prometheus.CounterValue, float64(v4.Null), "null")
+	ch <- prometheus.MustNewConstMetric(c.proc4, prometheus.CounterValue, float64(v4.Compound), "compound")
+
+	ops := stats.NFSdv4Ops
+	for op, v := range map[string]uint64{
+		"access": ops.Access, "close": ops.Close, "commit": ops.Commit, "create": ops.Create,
+		"delegpurge": ops.DelegPurge, "delegreturn": ops.DelegReturn, "getattr": ops.GetAttr,
+		"getfh": ops.GetFH, "link": ops.Link, "lock": ops.Lock, "lockt": ops.Lockt,
+		"locku": ops.Locku, "lookup": ops.Lookup, "lookuproot": ops.LookupRoot,
+		"nverify": ops.Nverify, "open": ops.Open, "openattr": ops.OpenAttr,
+		"openconfirm": ops.OpenConfirm, "opendowngrade": ops.OpenDgrd, "putfh": ops.PutFH,
+		"putpubfh": ops.PutPubFH, "putrootfh": ops.PutRootFH, "read": ops.Read,
+		"readdir": ops.ReadDir, "readlink": ops.ReadLink, "remove": ops.Remove,
+		"rename": ops.Rename, "renew": ops.Renew, "restorefh": ops.RestoreFH,
+		"savefh": ops.SaveFH, "secinfo": ops.SecInfo, "setattr": ops.SetAttr,
+		"verify": ops.Verify, "write": ops.Write, "rellockowner": ops.RelLockOwner,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.proc4ops, prometheus.CounterValue, float64(v), op)
+	}
+	for i, v := range ops.Extra {
+		ch <- prometheus.MustNewConstMetric(c.proc4ops, prometheus.CounterValue, float64(v), fmt.Sprintf("extra%d", i))
+	}
+}
+
+// readAheadHistogram renders the fixed 10-bucket read-ahead cache histogram
+// as a cumulative prometheus.Histogram-shaped metric, bucketed by the
+// fraction of the read-ahead depth at which the block was found (0.1 .. 1.0),
+// with NotFound folded into the +Inf bucket.
+func (c *NFSdCollector) readAheadHistogram(ra procfs.NFSdReadAheadCache) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(ra.CacheHistogram))
+	var sum float64
+	var cumulative uint64
+	for i, v := range ra.CacheHistogram {
+		cumulative += v
+		bound := float64(i+1) / 10
+		buckets[bound] = cumulative
+		// sum is the sum of observed values, not another count: each hit in
+		// this bucket is approximated by the bucket's upper bound.
+		sum += bound * float64(v)
+	}
+
+	return prometheus.MustNewConstHistogram(c.readAhead, cumulative+ra.NotFound, sum, buckets)
+}
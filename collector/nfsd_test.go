@@ -0,0 +1,34 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/AvengerMoJo/procfs"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestReadAheadHistogramSum(t *testing.T) {
+	c := &NFSdCollector{
+		readAhead: prometheus.NewDesc("test_read_ahead", "test", nil, nil),
+	}
+	ra := procfs.NFSdReadAheadCache{
+		CacheHistogram: [10]uint64{10, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		NotFound:       5,
+	}
+
+	var m dto.Metric
+	if err := c.readAheadHistogram(ra).Write(&m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	h := m.GetHistogram()
+	if got, want := h.GetSampleCount(), uint64(15); got != want {
+		t.Fatalf("SampleCount = %d, want %d", got, want)
+	}
+	// All 10 hits landed in the first bucket (bound 0.1), so the sum of
+	// observed values is 10*0.1 = 1, not the observation count (15).
+	if got, want := h.GetSampleSum(), 1.0; got != want {
+		t.Fatalf("SampleSum = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+// Package util provides low-level parsing helpers shared by this module's
+// /proc parsers. It exists so field parsing and error wrapping aren't
+// duplicated across every parser, and so that hot paths -- these types are
+// commonly scraped every few seconds inside a Prometheus Collect() call --
+// can avoid allocating a new []string per line.
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ParseUint64s parses every field in ss as a base-10 uint64.
+func ParseUint64s(ss []string) ([]uint64, error) {
+	us := make([]uint64, 0, len(ss))
+	for _, s := range ss {
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse %q as uint64: %w", s, err)
+		}
+		us = append(us, u)
+	}
+	return us, nil
+}
+
+// ParseUint64sBytes is ParseUint64s for fields that have already been sliced
+// out of a line as [][]byte, as produced by FieldReader.Fields. Unlike
+// strconv.ParseUint(string(f), ...), it never converts a field to a string,
+// so it doesn't allocate per field.
+func ParseUint64sBytes(fields [][]byte) ([]uint64, error) {
+	us := make([]uint64, 0, len(fields))
+	for _, f := range fields {
+		u, err := parseUint64Bytes(f)
+		if err != nil {
+			return nil, err
+		}
+		us = append(us, u)
+	}
+	return us, nil
+}
+
+// parseUint64Bytes parses b as a base-10 uint64 directly out of the byte
+// slice, without the string(b) conversion strconv.ParseUint would require.
+func parseUint64Bytes(b []byte) (uint64, error) {
+	if len(b) == 0 {
+		return 0, fmt.Errorf("couldn't parse %q as uint64: empty field", b)
+	}
+
+	var u uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("couldn't parse %q as uint64: invalid character %q", b, c)
+		}
+		d := uint64(c - '0')
+		if u > (math.MaxUint64-d)/10 {
+			return 0, fmt.Errorf("couldn't parse %q as uint64: value out of range", b)
+		}
+		u = u*10 + d
+	}
+	return u, nil
+}
+
+// ParseUint64Array splits line on spaces (a run of spaces counts as one
+// separator) and parses each field as a
+// base-10 uint64 directly out of the byte slice, without allocating a
+// []string or converting any field to a string. If expected is
+// non-negative, the number of fields found must match it exactly.
+//
+// This is a convenience for callers that already have a single line in
+// hand (e.g. a one-off parse, or a test fixture) and don't want to stand up
+// a FieldReader for it. The parsers in this module that scan multi-line
+// files use FieldReader+ParseUint64sBytes instead, since FieldReader reuses
+// its [][]byte field slice across lines; ParseUint64sBytes, like this
+// function, still allocates a new []uint64 per call.
+func ParseUint64Array(line []byte, expected int) ([]uint64, error) {
+	var us []uint64
+	if expected >= 0 {
+		us = make([]uint64, 0, expected)
+	}
+
+	rest := line
+	for len(rest) > 0 {
+		rest = bytes.TrimLeft(rest, " ")
+		if len(rest) == 0 {
+			break
+		}
+
+		var field []byte
+		if i := bytes.IndexByte(rest, ' '); i >= 0 {
+			field, rest = rest[:i], rest[i+1:]
+		} else {
+			field, rest = rest, nil
+		}
+
+		u, err := parseUint64Bytes(field)
+		if err != nil {
+			return nil, err
+		}
+		us = append(us, u)
+	}
+
+	if expected >= 0 && len(us) != expected {
+		return nil, fmt.Errorf("expected %d fields, got %d", expected, len(us))
+	}
+	return us, nil
+}
+
+// FieldReader scans whitespace-separated fields from an underlying reader
+// one line at a time. Unlike calling strings.Fields(scanner.Text()) per
+// line, it reuses its backing buffers across calls instead of allocating a
+// new []string (and a new copied string per field) for every line.
+type FieldReader struct {
+	scanner *bufio.Scanner
+	fields  [][]byte
+}
+
+// NewFieldReader returns a FieldReader reading from r.
+func NewFieldReader(r io.Reader) *FieldReader {
+	return &FieldReader{scanner: bufio.NewScanner(r)}
+}
+
+// Scan advances to the next line, as bufio.Scanner.Scan.
+func (fr *FieldReader) Scan() bool {
+	return fr.scanner.Scan()
+}
+
+// Err returns the first non-EOF error encountered while scanning.
+func (fr *FieldReader) Err() error {
+	return fr.scanner.Err()
+}
+
+// Line returns the current line, valid only until the next call to Scan.
+func (fr *FieldReader) Line() []byte {
+	return fr.scanner.Bytes()
+}
+
+// Fields splits the current line into whitespace-separated fields. The
+// returned slice, and the []byte fields within it, are only valid until the
+// next call to Scan.
+func (fr *FieldReader) Fields() [][]byte {
+	fr.fields = fr.fields[:0]
+
+	line := fr.scanner.Bytes()
+	for len(line) > 0 {
+		line = bytes.TrimLeft(line, " \t")
+		if len(line) == 0 {
+			break
+		}
+
+		i := bytes.IndexAny(line, " \t")
+		if i < 0 {
+			fr.fields = append(fr.fields, line)
+			break
+		}
+		fr.fields = append(fr.fields, line[:i])
+		line = line[i+1:]
+	}
+	return fr.fields
+}
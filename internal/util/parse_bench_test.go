@@ -0,0 +1,73 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+const benchNFSdLine = "proc4ops 72 2 2 0 29008 2 9379 0 0 0 19869 19869 0 0 19869 " +
+	"0 0 2 0 0 0 19869 0 19869 0 0 0 0 0 0 0 9940 0 0 0 0 0 0 0 19848 0 0 0 0 " +
+	"0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0"
+
+func BenchmarkParseUint64s(b *testing.B) {
+	fields := strings.Fields(benchNFSdLine)[1:]
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseUint64s(fields); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseUint64Array(b *testing.B) {
+	line := []byte(strings.TrimPrefix(benchNFSdLine, "proc4ops "))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseUint64Array(line, -1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkStringsFieldsPerLine models the old per-scrape cost: re-deriving
+// a []string with strings.Fields on every line, as the pre-util parsers did.
+func BenchmarkStringsFieldsPerLine(b *testing.B) {
+	lines := strings.Split(strings.TrimSpace(strings.Repeat(benchNFSdLine+"\n", 16)), "\n")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if _, err := ParseUint64s(fields[1:]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkFieldReaderPerLine models the new per-scrape cost: a FieldReader
+// reused across lines, with no per-line []string allocation and no per-field
+// string conversion (ParseUint64sBytes parses each []byte field in place).
+func BenchmarkFieldReaderPerLine(b *testing.B) {
+	data := strings.Repeat(benchNFSdLine+"\n", 16)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fr := NewFieldReader(strings.NewReader(data))
+		for fr.Scan() {
+			fields := fr.Fields()
+			if _, err := ParseUint64sBytes(fields[1:]); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := fr.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
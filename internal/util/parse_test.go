@@ -0,0 +1,95 @@
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUint64sBytes(t *testing.T) {
+	fields := [][]byte{[]byte("1"), []byte("2"), []byte("18446744073709551615")}
+	got, err := ParseUint64sBytes(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []uint64{1, 2, 18446744073709551615}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseUint64sBytesInvalid(t *testing.T) {
+	if _, err := ParseUint64sBytes([][]byte{[]byte("12a")}); err == nil {
+		t.Fatal("expected error for non-numeric field, got nil")
+	}
+	if _, err := ParseUint64sBytes([][]byte{[]byte("")}); err == nil {
+		t.Fatal("expected error for empty field, got nil")
+	}
+	// One past math.MaxUint64.
+	if _, err := ParseUint64sBytes([][]byte{[]byte("18446744073709551616")}); err == nil {
+		t.Fatal("expected error for overflowing field, got nil")
+	}
+}
+
+func TestParseUint64Array(t *testing.T) {
+	got, err := ParseUint64Array([]byte("1 2 3"), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []uint64{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParseUint64ArrayUnexpectedCount(t *testing.T) {
+	if _, err := ParseUint64Array([]byte("1 2 3"), 2); err == nil {
+		t.Fatal("expected error for field count mismatch, got nil")
+	}
+}
+
+func TestParseUint64ArrayNoExpectation(t *testing.T) {
+	got, err := ParseUint64Array([]byte("1 2 3"), -1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("len(got) = %d, want 3", len(got))
+	}
+}
+
+func TestFieldReaderFields(t *testing.T) {
+	fr := NewFieldReader(strings.NewReader("rc 1 2 3\nfh 1 2 3 4 5\n"))
+
+	if !fr.Scan() {
+		t.Fatal("expected a first line")
+	}
+	fields := fr.Fields()
+	if len(fields) != 4 || string(fields[0]) != "rc" {
+		t.Fatalf("unexpected fields on first line: %v", fields)
+	}
+
+	if !fr.Scan() {
+		t.Fatal("expected a second line")
+	}
+	fields = fr.Fields()
+	if len(fields) != 6 || string(fields[0]) != "fh" {
+		t.Fatalf("unexpected fields on second line: %v", fields)
+	}
+
+	if fr.Scan() {
+		t.Fatal("expected EOF after two lines")
+	}
+	if err := fr.Err(); err != nil {
+		t.Fatalf("unexpected scanner error: %v", err)
+	}
+}
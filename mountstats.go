@@ -0,0 +1,377 @@
+// /proc/self/mountstats parsing. Unlike /proc/net/rpc/nfs, which reports
+// client-wide totals, mountstats breaks NFS activity down per mount so that
+// per-operation latency can be attributed to the filesystem that caused it.
+package procfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AvengerMoJo/procfs/internal/util"
+)
+
+// NFSEventsStats contains the "events" counters from a mount's NFS
+// statistics, in the fixed order the kernel emits them.
+type NFSEventsStats struct {
+	InodeRevalidate     uint64
+	DnodeRevalidate     uint64
+	DataInvalidate      uint64
+	AttributeInvalidate uint64
+	VFSOpen             uint64
+	VFSLookup           uint64
+	VFSAccess           uint64
+	VFSUpdatePage       uint64
+	VFSReadPage         uint64
+	VFSReadPages        uint64
+	VFSWritePage        uint64
+	VFSWritePages       uint64
+	VFSGetdents         uint64
+	VFSSetattr          uint64
+	VFSFlush            uint64
+	VFSFsync            uint64
+	VFSLock             uint64
+	VFSFileRelease      uint64
+	CongestionWait      uint64
+	Truncation          uint64
+	WriteExtension      uint64
+	SillyRename         uint64
+	ShortRead           uint64
+	ShortWrite          uint64
+	JukeboxDelay        uint64
+	PNFSRead            uint64
+	PNFSWrite           uint64
+}
+
+// NFSBytesStats contains the "bytes" counters from a mount's NFS statistics.
+type NFSBytesStats struct {
+	NormalReadBytes  uint64
+	NormalWriteBytes uint64
+	DirectReadBytes  uint64
+	DirectWriteBytes uint64
+	ReadTotalBytes   uint64
+	WriteTotalBytes  uint64
+	ReadPages        uint64
+	WritePages       uint64
+}
+
+// NFSTransportStats contains the "xprt" line for a mount's RPC transport.
+// UDP transports leave Connect, ConnectIdleTime, IdleTimeSeconds and the
+// three slot/queue fields zero.
+type NFSTransportStats struct {
+	Protocol                 string
+	Port                     uint64
+	Bind                     uint64
+	Connect                  uint64
+	ConnectIdleTime          uint64
+	IdleTimeSeconds          uint64
+	Sends                    uint64
+	Receives                 uint64
+	BadTransactionIDs        uint64
+	CumulativeActiveRequests uint64
+	CumulativeBacklog        uint64
+	MaximumRPCSlotsUsed      uint64
+	CumulativeSendingQueue   uint64
+	CumulativePendingQueue   uint64
+}
+
+// NFSOperationStats contains the per-operation counters from a mount's "per-op
+// statistics" section.
+type NFSOperationStats struct {
+	Operation                           string
+	Requests                            uint64
+	Transmissions                       uint64
+	MajorTimeouts                       uint64
+	BytesSent                           uint64
+	BytesReceived                       uint64
+	CumulativeQueueMilliseconds         uint64
+	CumulativeTotalResponseMilliseconds uint64
+	CumulativeTotalRequestMilliseconds  uint64
+}
+
+// NFSMount is a single entry from /proc/self/mountstats: one NFS-mounted
+// filesystem and the statistics the kernel has collected for it.
+type NFSMount struct {
+	Device      string
+	MountPoint  string
+	Type        string
+	StatVersion string
+	Age         time.Duration
+	Events      NFSEventsStats
+	Bytes       NFSBytesStats
+	Transport   NFSTransportStats
+	Operations  []NFSOperationStats
+}
+
+const (
+	fieldTransport10TCP  = 10
+	fieldTransport11UDP  = 7
+	fieldTransport13Ext  = 13
+	fieldEventsLen       = 27
+	fieldBytesLen        = 8
+)
+
+// NewNFSMountStats returns per-mount NFS statistics read from
+// /proc/self/mountstats. Non-NFS mounts are skipped.
+func (fs FS) NewNFSMountStats() ([]NFSMount, error) {
+	f, err := os.Open(fs.Path("self/mountstats"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []NFSMount
+
+	scanner := bufio.NewScanner(f)
+	// pending is set when parseNFSMountBody stopped on an unindented line
+	// (usually the next mount's device line) that still needs handling.
+	pending := false
+	for pending || scanner.Scan() {
+		pending = false
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "device" {
+			continue
+		}
+
+		mount, err := parseNFSMountHeader(fields)
+		if err != nil {
+			return nil, err
+		}
+		if mount == nil {
+			// Not an NFS mount; skip its body, if any, and move on.
+			continue
+		}
+
+		if err := parseNFSMountBody(scanner, mount); err != nil {
+			return nil, fmt.Errorf("error parsing mountstats for %s: %w", mount.MountPoint, err)
+		}
+
+		mounts = append(mounts, *mount)
+		pending = scanner.Text() != ""
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %w", f.Name(), err)
+	}
+
+	return mounts, nil
+}
+
+// parseNFSMountHeader parses a "device ... mounted on ... with fstype ..."
+// line. It returns a nil *NFSMount (and no error) for non-NFS filesystems.
+func parseNFSMountHeader(fields []string) (*NFSMount, error) {
+	// device <device> mounted on <mount point> with fstype <type> [statvers=<ver>]
+	if len(fields) < 8 || fields[2] != "mounted" || fields[3] != "on" || fields[5] != "with" || fields[6] != "fstype" {
+		return nil, fmt.Errorf("invalid mountstats device line %q", strings.Join(fields, " "))
+	}
+
+	mount := &NFSMount{
+		Device:     fields[1],
+		MountPoint: fields[4],
+		Type:       fields[7],
+	}
+	if !strings.HasPrefix(mount.Type, "nfs") {
+		return nil, nil
+	}
+	if len(fields) > 8 {
+		mount.StatVersion = strings.TrimPrefix(fields[8], "statvers=")
+	}
+	return mount, nil
+}
+
+// parseNFSMountBody reads the indented statistics block that follows an NFS
+// mount's device line, stopping at the next unindented line or EOF.
+func parseNFSMountBody(scanner *bufio.Scanner, mount *NFSMount) error {
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, " ") {
+			return nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch {
+		case fields[0] == "age:":
+			if len(fields) < 2 {
+				err = fmt.Errorf("invalid NFS age line %q", strings.Join(fields, " "))
+				break
+			}
+			var seconds uint64
+			seconds, err = strconv.ParseUint(fields[1], 10, 64)
+			mount.Age = time.Duration(seconds) * time.Second
+		case fields[0] == "events:":
+			err = parseNFSEventsStats(fields[1:], mount)
+		case fields[0] == "bytes:":
+			err = parseNFSBytesStats(fields[1:], mount)
+		case fields[0] == "xprt:":
+			err = parseNFSTransportStats(fields[1:], mount)
+		case strings.HasSuffix(fields[0], ":") && len(fields) > 1 && isUint64Slice(fields[1:]):
+			err = parseNFSOperationStats(fields, mount)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isUint64Slice(fields []string) bool {
+	for _, f := range fields {
+		if _, err := strconv.ParseUint(f, 10, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func parseNFSEventsStats(fields []string, mount *NFSMount) error {
+	if len(fields) != fieldEventsLen {
+		return fmt.Errorf("invalid NFS events line: expected %d fields, got %d", fieldEventsLen, len(fields))
+	}
+	v, err := util.ParseUint64s(fields)
+	if err != nil {
+		return err
+	}
+	mount.Events = NFSEventsStats{
+		InodeRevalidate:     v[0],
+		DnodeRevalidate:     v[1],
+		DataInvalidate:      v[2],
+		AttributeInvalidate: v[3],
+		VFSOpen:             v[4],
+		VFSLookup:           v[5],
+		VFSAccess:           v[6],
+		VFSUpdatePage:       v[7],
+		VFSReadPage:         v[8],
+		VFSReadPages:        v[9],
+		VFSWritePage:        v[10],
+		VFSWritePages:       v[11],
+		VFSGetdents:         v[12],
+		VFSSetattr:          v[13],
+		VFSFlush:            v[14],
+		VFSFsync:            v[15],
+		VFSLock:             v[16],
+		VFSFileRelease:      v[17],
+		CongestionWait:      v[18],
+		Truncation:          v[19],
+		WriteExtension:      v[20],
+		SillyRename:         v[21],
+		ShortRead:           v[22],
+		ShortWrite:          v[23],
+		JukeboxDelay:        v[24],
+		PNFSRead:            v[25],
+		PNFSWrite:           v[26],
+	}
+	return nil
+}
+
+func parseNFSBytesStats(fields []string, mount *NFSMount) error {
+	if len(fields) != fieldBytesLen {
+		return fmt.Errorf("invalid NFS bytes line: expected %d fields, got %d", fieldBytesLen, len(fields))
+	}
+	v, err := util.ParseUint64s(fields)
+	if err != nil {
+		return err
+	}
+	mount.Bytes = NFSBytesStats{
+		NormalReadBytes:  v[0],
+		NormalWriteBytes: v[1],
+		DirectReadBytes:  v[2],
+		DirectWriteBytes: v[3],
+		ReadTotalBytes:   v[4],
+		WriteTotalBytes:  v[5],
+		ReadPages:        v[6],
+		WritePages:       v[7],
+	}
+	return nil
+}
+
+// parseNFSTransportStats parses an "xprt:" line. Its length depends on the
+// transport protocol: UDP reports fewer fields than TCP, and on any modern
+// (statvers 1.1) kernel both TCP and RDMA report 3 extra slot/queue fields
+// beyond the original 10.
+func parseNFSTransportStats(fields []string, mount *NFSMount) error {
+	if len(fields) < 1 {
+		return fmt.Errorf("invalid NFS xprt line %q", fields)
+	}
+	protocol := fields[0]
+	v, err := util.ParseUint64s(fields[1:])
+	if err != nil {
+		return err
+	}
+
+	stats := NFSTransportStats{Protocol: protocol}
+	switch protocol {
+	case "udp":
+		if len(v) < fieldTransport11UDP {
+			return fmt.Errorf("invalid NFS xprt udp line: expected at least %d fields, got %d", fieldTransport11UDP, len(v))
+		}
+		stats.Port = v[0]
+		stats.Bind = v[1]
+		stats.Sends = v[2]
+		stats.Receives = v[3]
+		stats.BadTransactionIDs = v[4]
+		stats.CumulativeActiveRequests = v[5]
+		stats.CumulativeBacklog = v[6]
+	case "tcp", "rdma":
+		if len(v) < fieldTransport10TCP {
+			return fmt.Errorf("invalid NFS xprt %s line: expected at least %d fields, got %d", protocol, fieldTransport10TCP, len(v))
+		}
+		stats.Port = v[0]
+		stats.Bind = v[1]
+		stats.Connect = v[2]
+		stats.ConnectIdleTime = v[3]
+		stats.IdleTimeSeconds = v[4]
+		stats.Sends = v[5]
+		stats.Receives = v[6]
+		stats.BadTransactionIDs = v[7]
+		stats.CumulativeActiveRequests = v[8]
+		stats.CumulativeBacklog = v[9]
+		// On any modern (statvers 1.1) kernel, tcp carries these 3 extra
+		// fields too, not just rdma.
+		if len(v) >= fieldTransport13Ext {
+			stats.MaximumRPCSlotsUsed = v[10]
+			stats.CumulativeSendingQueue = v[11]
+			stats.CumulativePendingQueue = v[12]
+		}
+	default:
+		return fmt.Errorf("unrecognized NFS transport protocol %q", protocol)
+	}
+
+	mount.Transport = stats
+	return nil
+}
+
+// parseNFSOperationStats parses one line of the "per-op statistics" section,
+// e.g. "READ: 1 1 0 128 2477 4 4 0".
+func parseNFSOperationStats(fields []string, mount *NFSMount) error {
+	v, err := util.ParseUint64s(fields[1:])
+	if err != nil {
+		return err
+	}
+	if len(v) < 8 {
+		return fmt.Errorf("invalid NFS per-op line %q", fields)
+	}
+
+	mount.Operations = append(mount.Operations, NFSOperationStats{
+		Operation:                           strings.TrimSuffix(fields[0], ":"),
+		Requests:                            v[0],
+		Transmissions:                       v[1],
+		MajorTimeouts:                       v[2],
+		BytesSent:                           v[3],
+		BytesReceived:                       v[4],
+		CumulativeQueueMilliseconds:         v[5],
+		CumulativeTotalResponseMilliseconds: v[6],
+		CumulativeTotalRequestMilliseconds:  v[7],
+	})
+	return nil
+}
@@ -0,0 +1,89 @@
+package procfs
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseNFSMountHeader(t *testing.T) {
+	mount, err := parseNFSMountHeader(strings.Fields(
+		"device server:/export mounted on /mnt with fstype nfs4 statvers=1.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mount == nil {
+		t.Fatal("expected a non-nil mount for an nfs4 fstype")
+	}
+	if mount.Device != "server:/export" || mount.MountPoint != "/mnt" || mount.StatVersion != "1.1" {
+		t.Fatalf("unexpected mount: %+v", mount)
+	}
+}
+
+func TestParseNFSMountHeaderNonNFS(t *testing.T) {
+	mount, err := parseNFSMountHeader(strings.Fields(
+		"device /dev/sda1 mounted on / with fstype ext4"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mount != nil {
+		t.Fatalf("expected nil mount for a non-nfs fstype, got %+v", mount)
+	}
+}
+
+// TestParseNFSMountBodyAgeNoValue reproduces a panic found in review: an
+// "age:" line with no value must return an error, not index out of range.
+func TestParseNFSMountBodyAgeNoValue(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("\tage:\n"))
+	mount := &NFSMount{}
+	if err := parseNFSMountBody(scanner, mount); err == nil {
+		t.Fatal("expected error for age line with no value, got nil")
+	}
+}
+
+func TestParseNFSMountBodyXprtVariants(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		line string
+	}{
+		{"udp", "\txprt: udp 0 1 2 3 4 5 6\n"},
+		// Real statvers 1.1 tcp line: 13 fields, the same extended set rdma
+		// carries, not the bare 10-field minimum.
+		{"tcp", "\txprt: tcp 832 0 1 0 11 6428 6428 0 12154 0 24 26 5726\n"},
+		{"rdma", "\txprt: rdma 20049 1 0 0 2 100 99 0 5 4 8 0 0\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			scanner := bufio.NewScanner(strings.NewReader(tc.line))
+			mount := &NFSMount{}
+			if err := parseNFSMountBody(scanner, mount); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mount.Transport.Protocol != tc.name {
+				t.Fatalf("Protocol = %q, want %q", mount.Transport.Protocol, tc.name)
+			}
+		})
+	}
+}
+
+func TestParseNFSMountBodyXprtTCPExtendedFields(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("\txprt: tcp 832 0 1 0 11 6428 6428 0 12154 0 24 26 5726\n"))
+	mount := &NFSMount{}
+	if err := parseNFSMountBody(scanner, mount); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	xprt := mount.Transport
+	if xprt.MaximumRPCSlotsUsed != 24 || xprt.CumulativeSendingQueue != 26 || xprt.CumulativePendingQueue != 5726 {
+		t.Fatalf("extended tcp xprt fields not populated: %+v", xprt)
+	}
+}
+
+func TestParseNFSMountBodyPerOp(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("\tREAD: 1 1 0 128 2477 4 4 0\n"))
+	mount := &NFSMount{}
+	if err := parseNFSMountBody(scanner, mount); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mount.Operations) != 1 || mount.Operations[0].Operation != "READ" {
+		t.Fatalf("unexpected Operations: %+v", mount.Operations)
+	}
+}
@@ -0,0 +1,303 @@
+// /proc/net/rpc/nfs parsing, the client-side counterpart to
+// /proc/net/rpc/nfsd. Documented by https://www.svennd.be/nfsd-stats-explained-procnetrpcnfsd/
+package procfs
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/AvengerMoJo/procfs/internal/util"
+)
+
+// net line: Network
+type NFSClientNetwork struct {
+	NetCount   uint64
+	UDPCount   uint64
+	TCPCount   uint64
+	TCPConnect uint64
+}
+
+// rpc line: RPC
+type NFSClientRPC struct {
+	RPCCount        uint64
+	Retransmissions uint64
+	AuthRefreshes   uint64
+}
+
+// proc2 line: NFSv2 client operation counters
+type NFSClientV2Stats struct {
+	Values   uint64 // Should be 18.
+	Null     uint64
+	GetAttr  uint64
+	SetAttr  uint64
+	Root     uint64
+	Lookup   uint64
+	ReadLink uint64
+	Read     uint64
+	WrCache  uint64
+	Write    uint64
+	Create   uint64
+	Remove   uint64
+	Rename   uint64
+	Link     uint64
+	SymLink  uint64
+	MkDir    uint64
+	RmDir    uint64
+	ReadDir  uint64
+	FsStat   uint64
+}
+
+// proc3 line: NFSv3 client operation counters
+type NFSClientV3Stats struct {
+	Values      uint64 // Should be 22.
+	Null        uint64
+	GetAttr     uint64
+	SetAttr     uint64
+	Lookup      uint64
+	Access      uint64
+	ReadLink    uint64
+	Read        uint64
+	Write       uint64
+	Create      uint64
+	MkDir       uint64
+	SymLink     uint64
+	MkNod       uint64
+	Remove      uint64
+	RmDir       uint64
+	Rename      uint64
+	Link        uint64
+	ReadDir     uint64
+	ReadDirPlus uint64
+	FsStat      uint64
+	FsInfo      uint64
+	PathConf    uint64
+	Commit      uint64
+}
+
+// proc4 line: NFSv4 client operation counters.
+// Variable-length: the kernel appends newly implemented operations to the
+// tail as NFSv4 minor versions gain features, so only the stable leading
+// set is named here. Anything past that is kept in Extra, in wire order.
+type NFSClientV4Stats struct {
+	Values             uint64 // Variable depending on kernel version.
+	Null               uint64
+	Read               uint64
+	Write              uint64
+	Commit             uint64
+	Open               uint64
+	OpenConfirm        uint64
+	OpenNoattr         uint64
+	OpenDowngrade      uint64
+	Close              uint64
+	SetAttr            uint64
+	FsInfo             uint64
+	Renew              uint64
+	SetClientID        uint64
+	SetClientIDConfirm uint64
+	Lock               uint64
+	Lockt              uint64
+	Locku              uint64
+	Access             uint64
+	GetAttr            uint64
+	Lookup             uint64
+	LookupRoot         uint64
+	Remove             uint64
+	Rename             uint64
+	Link               uint64
+	SymLink            uint64
+	Create             uint64
+	PathConf           uint64
+	StatFs             uint64
+	ReadLink           uint64
+	ReadDir            uint64
+	ServerCaps         uint64
+	DelegReturn        uint64
+	GetACL             uint64
+	SetACL             uint64
+	FsLocations        uint64
+	ReleaseLockOwner   uint64
+	SecInfo            uint64
+	FsidPresent        uint64
+	Extra              []uint64
+}
+
+// nfsClientV4OpFields lists, in on-the-wire order, the named fields of
+// NFSClientV4Stats that proc4 reports.
+func nfsClientV4OpFields(stats *NFSClientV4Stats) []*uint64 {
+	return []*uint64{
+		&stats.Null, &stats.Read, &stats.Write, &stats.Commit, &stats.Open,
+		&stats.OpenConfirm, &stats.OpenNoattr, &stats.OpenDowngrade, &stats.Close,
+		&stats.SetAttr, &stats.FsInfo, &stats.Renew, &stats.SetClientID,
+		&stats.SetClientIDConfirm, &stats.Lock, &stats.Lockt, &stats.Locku,
+		&stats.Access, &stats.GetAttr, &stats.Lookup, &stats.LookupRoot,
+		&stats.Remove, &stats.Rename, &stats.Link, &stats.SymLink, &stats.Create,
+		&stats.PathConf, &stats.StatFs, &stats.ReadLink, &stats.ReadDir,
+		&stats.ServerCaps, &stats.DelegReturn, &stats.GetACL, &stats.SetACL,
+		&stats.FsLocations, &stats.ReleaseLockOwner, &stats.SecInfo, &stats.FsidPresent,
+	}
+}
+
+// All stats from /proc/net/rpc/nfs
+type NFSClientRPCStats struct {
+	NFSClientNetwork NFSClientNetwork
+	NFSClientRPC     NFSClientRPC
+	NFSClientV2Stats NFSClientV2Stats
+	NFSClientV3Stats NFSClientV3Stats
+	NFSClientV4Stats NFSClientV4Stats
+}
+
+func parseNFSClientNetwork(v []uint64) (NFSClientNetwork, error) {
+	if len(v) != 4 {
+		return NFSClientNetwork{}, fmt.Errorf("invalid NFSClientNetwork line %v", v)
+	}
+	return NFSClientNetwork{
+		NetCount:   v[0],
+		UDPCount:   v[1],
+		TCPCount:   v[2],
+		TCPConnect: v[3],
+	}, nil
+}
+
+func parseNFSClientRPC(v []uint64) (NFSClientRPC, error) {
+	if len(v) != 3 {
+		return NFSClientRPC{}, fmt.Errorf("invalid NFSClientRPC line %v", v)
+	}
+	return NFSClientRPC{
+		RPCCount:        v[0],
+		Retransmissions: v[1],
+		AuthRefreshes:   v[2],
+	}, nil
+}
+
+func parseNFSClientV2Stats(v []uint64) (NFSClientV2Stats, error) {
+	if len(v) != 19 {
+		return NFSClientV2Stats{}, fmt.Errorf("invalid NFSClientV2Stats line %v", v)
+	}
+	return NFSClientV2Stats{
+		Values:   v[0],
+		Null:     v[1],
+		GetAttr:  v[2],
+		SetAttr:  v[3],
+		Root:     v[4],
+		Lookup:   v[5],
+		ReadLink: v[6],
+		Read:     v[7],
+		WrCache:  v[8],
+		Write:    v[9],
+		Create:   v[10],
+		Remove:   v[11],
+		Rename:   v[12],
+		Link:     v[13],
+		SymLink:  v[14],
+		MkDir:    v[15],
+		RmDir:    v[16],
+		ReadDir:  v[17],
+		FsStat:   v[18],
+	}, nil
+}
+
+func parseNFSClientV3Stats(v []uint64) (NFSClientV3Stats, error) {
+	if len(v) != 23 {
+		return NFSClientV3Stats{}, fmt.Errorf("invalid NFSClientV3Stats line %v", v)
+	}
+	return NFSClientV3Stats{
+		Values:      v[0],
+		Null:        v[1],
+		GetAttr:     v[2],
+		SetAttr:     v[3],
+		Lookup:      v[4],
+		Access:      v[5],
+		ReadLink:    v[6],
+		Read:        v[7],
+		Write:       v[8],
+		Create:      v[9],
+		MkDir:       v[10],
+		SymLink:     v[11],
+		MkNod:       v[12],
+		Remove:      v[13],
+		RmDir:       v[14],
+		Rename:      v[15],
+		Link:        v[16],
+		ReadDir:     v[17],
+		ReadDirPlus: v[18],
+		FsStat:      v[19],
+		FsInfo:      v[20],
+		PathConf:    v[21],
+		Commit:      v[22],
+	}, nil
+}
+
+// parseNFSClientV4Stats parses a proc4 line. Like proc4ops on the server
+// side, this is variable-length: the first value is the number of
+// operations that follow, which grows as the kernel's NFSv4 client gains
+// support for new operations.
+func parseNFSClientV4Stats(v []uint64) (NFSClientV4Stats, error) {
+	if len(v) < 1 {
+		return NFSClientV4Stats{}, fmt.Errorf("invalid NFSClientV4Stats line %v", v)
+	}
+	values := v[0]
+	ops := v[1:]
+	if uint64(len(ops)) != values {
+		return NFSClientV4Stats{}, fmt.Errorf("invalid NFSClientV4Stats line: header says %d operations, got %d", values, len(ops))
+	}
+
+	stats := NFSClientV4Stats{Values: values}
+	fields := nfsClientV4OpFields(&stats)
+	for i, op := range ops {
+		if i < len(fields) {
+			*fields[i] = op
+			continue
+		}
+		stats.Extra = append(stats.Extra, op)
+	}
+	return stats, nil
+}
+
+// NewNFSClientRPCStats returns stats read from /proc/net/rpc/nfs
+func (fs FS) NewNFSClientRPCStats() (NFSClientRPCStats, error) {
+	f, err := os.Open(fs.Path("net/rpc/nfs"))
+	if err != nil {
+		return NFSClientRPCStats{}, err
+	}
+	defer f.Close()
+
+	stats := NFSClientRPCStats{}
+
+	fr := util.NewFieldReader(f)
+	for fr.Scan() {
+		parts := fr.Fields()
+		// require at least <key> <value>
+		if len(parts) < 2 {
+			return NFSClientRPCStats{}, fmt.Errorf("invalid NFS client metric line %q", fr.Line())
+		}
+
+		values, err := util.ParseUint64sBytes(parts[1:])
+		if err != nil {
+			return NFSClientRPCStats{}, fmt.Errorf("error parsing NFS client metric line %q: %w", fr.Line(), err)
+		}
+
+		switch metricLine := string(parts[0]); metricLine {
+		case "net":
+			stats.NFSClientNetwork, err = parseNFSClientNetwork(values)
+		case "rpc":
+			stats.NFSClientRPC, err = parseNFSClientRPC(values)
+		case "proc2":
+			stats.NFSClientV2Stats, err = parseNFSClientV2Stats(values)
+		case "proc3":
+			stats.NFSClientV3Stats, err = parseNFSClientV3Stats(values)
+		case "proc4":
+			stats.NFSClientV4Stats, err = parseNFSClientV4Stats(values)
+		default:
+			err = fmt.Errorf("invalid NFS client metric line %q", metricLine)
+		}
+		if err != nil {
+			return NFSClientRPCStats{}, fmt.Errorf("error parsing NFS client metric line %q: %w", fr.Line(), err)
+		}
+	}
+
+	if err := fr.Err(); err != nil {
+		return NFSClientRPCStats{}, fmt.Errorf("couldn't parse %s: %w", f.Name(), err)
+	}
+
+	return stats, nil
+}
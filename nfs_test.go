@@ -0,0 +1,48 @@
+package procfs
+
+import "testing"
+
+func TestParseNFSClientV4StatsVariableLength(t *testing.T) {
+	fixed := len(nfsClientV4OpFields(&NFSClientV4Stats{}))
+	for _, n := range []int{fixed, fixed + 5} {
+		v := make([]uint64, n+1)
+		v[0] = uint64(n)
+		for i := 1; i < len(v); i++ {
+			v[i] = uint64(i)
+		}
+
+		stats, err := parseNFSClientV4Stats(v)
+		if err != nil {
+			t.Fatalf("n=%d: unexpected error: %v", n, err)
+		}
+		if stats.Values != uint64(n) {
+			t.Fatalf("n=%d: Values = %d, want %d", n, stats.Values, n)
+		}
+		if want := n - fixed; len(stats.Extra) != want {
+			t.Fatalf("n=%d: len(Extra) = %d, want %d", n, len(stats.Extra), want)
+		}
+	}
+}
+
+func TestParseNFSClientV4StatsLengthMismatch(t *testing.T) {
+	if _, err := parseNFSClientV4Stats([]uint64{3, 1, 2}); err == nil {
+		t.Fatal("expected error for header/value count mismatch, got nil")
+	}
+}
+
+func TestParseNFSClientNetwork(t *testing.T) {
+	got, err := parseNFSClientNetwork([]uint64{100, 1, 99, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := NFSClientNetwork{NetCount: 100, UDPCount: 1, TCPCount: 99, TCPConnect: 2}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNFSClientRPC(t *testing.T) {
+	if _, err := parseNFSClientRPC([]uint64{1, 2}); err == nil {
+		t.Fatal("expected error for short NFSClientRPC line, got nil")
+	}
+}
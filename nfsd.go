@@ -2,9 +2,12 @@
 package procfs
 
 import (
-	"bufio"
 	"fmt"
 	"io"
+	"os"
+	"strconv"
+
+	"github.com/AvengerMoJo/procfs/internal/util"
 )
 
 // rc line: Reply Cache
@@ -33,6 +36,10 @@ type NFSdInputOutput struct {
 type NFSdThreads struct {
 	Threads uint64
 	FullCnt uint64
+	// ThreadsHistogram holds the 10 floating-point fields that follow
+	// FullCnt: the fraction of time 0-10%, 10-20%, ... 90-100% of the
+	// server's threads were in use.
+	ThreadsHistogram [10]float64
 }
 
 // ra line: Read Ahead Cache
@@ -161,6 +168,25 @@ type NFSdv4Ops struct {
 	Verify       uint64
 	Write        uint64
 	RelLockOwner uint64
+	// Extra holds operations introduced by v4.1/v4.2 (and later) that don't
+	// yet have a named field above, in on-the-wire order.
+	Extra []uint64
+}
+
+// nfsdv4OpFields lists, in on-the-wire order, the named fields of
+// NFSdv4Ops that proc4ops reports. Operations beyond this list (added by
+// later minor versions) are collected into NFSdv4Ops.Extra instead.
+func nfsdv4OpFields(stats *NFSdv4Ops) []*uint64 {
+	return []*uint64{
+		&stats.Op0Unused, &stats.Op1Unused, &stats.Op2Future, &stats.Access, &stats.Close,
+		&stats.Commit, &stats.Create, &stats.DelegPurge, &stats.DelegReturn, &stats.GetAttr,
+		&stats.GetFH, &stats.Link, &stats.Lock, &stats.Lockt, &stats.Locku, &stats.Lookup,
+		&stats.LookupRoot, &stats.Nverify, &stats.Open, &stats.OpenAttr, &stats.OpenConfirm,
+		&stats.OpenDgrd, &stats.PutFH, &stats.PutPubFH, &stats.PutRootFH, &stats.Read,
+		&stats.ReadDir, &stats.ReadLink, &stats.Remove, &stats.Rename, &stats.Renew,
+		&stats.RestoreFH, &stats.SaveFH, &stats.SecInfo, &stats.SetAttr, &stats.Verify,
+		&stats.Write, &stats.RelLockOwner,
+	}
 }
 
 // All stats from /proc/net/rpc/nfsd
@@ -176,74 +202,281 @@ type NFSdRPCStats struct {
 	NFSdv3Stats        NFSdv3Stats
 	NFSdv4Stats        NFSdv4Stats
 	NFSdv4Ops          NFSdv4Ops
-	NFSdRPCStats       NFSdRPCStats
 }
 
-func parseNFSdReplyCache(line []byte) (NFSdReplyCache, err) {
-	if len(line) != 3 {
-		return nil, fmt.Errorf("invalid NFSdReplyCache line %q", line)
+func parseNFSdReplyCache(v []uint64) (NFSdReplyCache, error) {
+	if len(v) != 3 {
+		return NFSdReplyCache{}, fmt.Errorf("invalid NFSdReplyCache line %v", v)
 	}
-	hits, err := strconv.ParseInt(line[0])
-	if err != nil {
-		return nil, fmt.Errorf("couldn't parse NFSdReplyCache hits %q", line[0])
+	return NFSdReplyCache{
+		Hits:    v[0],
+		Misses:  v[1],
+		NoCache: v[2],
+	}, nil
+}
+
+func parseNFSdFileHandles(v []uint64) (NFSdFileHandles, error) {
+	if len(v) != 5 {
+		return NFSdFileHandles{}, fmt.Errorf("invalid NFSdFileHandles line %v", v)
 	}
-	misses, err := strconv.ParseInt(line[1])
-	if err != nil {
-		return nil, fmt.Errorf("couldn't parse NFSdReplyCache misses %q", line[1])
+	return NFSdFileHandles{
+		Stale:        v[0],
+		TotalLookups: v[1],
+		AnonLookups:  v[2],
+		DirNoCache:   v[3],
+		NoDirNoCache: v[4],
+	}, nil
+}
+
+func parseNFSdInputOutput(v []uint64) (NFSdInputOutput, error) {
+	if len(v) != 2 {
+		return NFSdInputOutput{}, fmt.Errorf("invalid NFSdInputOutput line %v", v)
 	}
-	nocache, err := strconv.ParseInt(line[2])
+	return NFSdInputOutput{
+		Read:  v[0],
+		Write: v[1],
+	}, nil
+}
+
+// parseNFSdThreads parses a "th" line. Unlike every other line in
+// /proc/net/rpc/nfsd, it carries floating-point fields: after the two
+// thread counters comes a 10-bucket histogram of thread utilization, so it
+// can't go through the uint64 fast path the rest of this file uses.
+func parseNFSdThreads(fields [][]byte) (NFSdThreads, error) {
+	if len(fields) < 2 {
+		return NFSdThreads{}, fmt.Errorf("invalid NFSdThreads line %q", fields)
+	}
+
+	v, err := util.ParseUint64sBytes(fields[:2])
 	if err != nil {
-		return nil, fmt.Errorf("couldn't parse NFSdReplyCache nocache %q", line[2])
+		return NFSdThreads{}, fmt.Errorf("invalid NFSdThreads line %q: %w", fields, err)
 	}
-	stat := NFSdReplyCache{
-		Hits: hits
-		Misses: misses
-		NoCache: nocache
+
+	stats := NFSdThreads{
+		Threads: v[0],
+		FullCnt: v[1],
+	}
+
+	histogram := fields[2:]
+	for i := 0; i < len(histogram) && i < len(stats.ThreadsHistogram); i++ {
+		f, err := strconv.ParseFloat(string(histogram[i]), 64)
+		if err != nil {
+			return NFSdThreads{}, fmt.Errorf("invalid NFSdThreads histogram field %q: %w", histogram[i], err)
+		}
+		stats.ThreadsHistogram[i] = f
+	}
+
+	return stats, nil
+}
+
+func parseNFSdReadAheadCache(v []uint64) (NFSdReadAheadCache, error) {
+	if len(v) != 12 {
+		return NFSdReadAheadCache{}, fmt.Errorf("invalid NFSdReadAheadCache line %v", v)
+	}
+	stat := NFSdReadAheadCache{
+		CacheSize: v[0],
+		NotFound:  v[11],
 	}
+	copy(stat.CacheHistogram[:], v[1:11])
 	return stat, nil
 }
 
+func parseNFSdNetwork(v []uint64) (NFSdNetwork, error) {
+	if len(v) != 4 {
+		return NFSdNetwork{}, fmt.Errorf("invalid NFSdNetwork line %v", v)
+	}
+	return NFSdNetwork{
+		NetCount:   v[0],
+		UDPCount:   v[1],
+		TCPCount:   v[2],
+		TCPConnect: v[3],
+	}, nil
+}
+
+func parseNFSdRPC(v []uint64) (NFSdRPC, error) {
+	if len(v) != 5 {
+		return NFSdRPC{}, fmt.Errorf("invalid NFSdRPC line %v", v)
+	}
+	return NFSdRPC{
+		RPCCount: v[0],
+		BadCnt:   v[1],
+		BadFmt:   v[2],
+		BadAuth:  v[3],
+		BadcInt:  v[4],
+	}, nil
+}
+
+func parseNFSdv2Stats(v []uint64) (NFSdv2Stats, error) {
+	if len(v) != 19 {
+		return NFSdv2Stats{}, fmt.Errorf("invalid NFSdv2Stats line %v", v)
+	}
+	return NFSdv2Stats{
+		Values:   v[0],
+		Null:     v[1],
+		GetAttr:  v[2],
+		SetAttr:  v[3],
+		Root:     v[4],
+		Lookup:   v[5],
+		ReadLink: v[6],
+		Read:     v[7],
+		WrCache:  v[8],
+		Write:    v[9],
+		Create:   v[10],
+		Remove:   v[11],
+		Rename:   v[12],
+		Link:     v[13],
+		SymLink:  v[14],
+		MkDir:    v[15],
+		RmDir:    v[16],
+		ReadDir:  v[17],
+		FsStat:   v[18],
+	}, nil
+}
+
+func parseNFSdv3Stats(v []uint64) (NFSdv3Stats, error) {
+	if len(v) != 23 {
+		return NFSdv3Stats{}, fmt.Errorf("invalid NFSdv3Stats line %v", v)
+	}
+	return NFSdv3Stats{
+		Values:      v[0],
+		Null:        v[1],
+		GetAttr:     v[2],
+		SetAttr:     v[3],
+		Lookup:      v[4],
+		Access:      v[5],
+		ReadLink:    v[6],
+		Read:        v[7],
+		Write:       v[8],
+		Create:      v[9],
+		MkDir:       v[10],
+		SymLink:     v[11],
+		MkNod:       v[12],
+		Remove:      v[13],
+		RmDir:       v[14],
+		Rename:      v[15],
+		Link:        v[16],
+		ReadDir:     v[17],
+		ReadDirPlus: v[18],
+		FsStat:      v[19],
+		FsInfo:      v[20],
+		PathConf:    v[21],
+		Commit:      v[22],
+	}, nil
+}
+
+func parseNFSdv4Stats(v []uint64) (NFSdv4Stats, error) {
+	if len(v) != 3 {
+		return NFSdv4Stats{}, fmt.Errorf("invalid NFSdv4Stats line %v", v)
+	}
+	return NFSdv4Stats{
+		Values:   v[0],
+		Null:     v[1],
+		Compound: v[2],
+	}, nil
+}
+
+// parseNFSdv4Ops parses a proc4ops line. The line is variable-length: the
+// first value is the number of operations that follow, which differs
+// between NFSv4 minor versions (38 for v4.0, 58 for v4.1, 71 for the v4.2
+// draft). Known operations are assigned to their named field; anything
+// beyond the named fields is appended to Extra in wire order.
+func parseNFSdv4Ops(v []uint64) (NFSdv4Ops, error) {
+	if len(v) < 1 {
+		return NFSdv4Ops{}, fmt.Errorf("invalid NFSdv4Ops line %v", v)
+	}
+	values := v[0]
+	ops := v[1:]
+	if uint64(len(ops)) != values {
+		return NFSdv4Ops{}, fmt.Errorf("invalid NFSdv4Ops line: header says %d operations, got %d", values, len(ops))
+	}
+
+	stats := NFSdv4Ops{Values: values}
+	fields := nfsdv4OpFields(&stats)
+	for i, op := range ops {
+		if i < len(fields) {
+			*fields[i] = op
+			continue
+		}
+		stats.Extra = append(stats.Extra, op)
+	}
+	return stats, nil
+}
+
 // NewNFSdRPCStats returns stats read from /proc/net/rpc/nfsd
-func (fs FS) NewNFSdRPCStats() (NFSdRPCStats, err) {
+func (fs FS) NewNFSdRPCStats() (NFSdRPCStats, error) {
 	f, err := os.Open(fs.Path("net/rpc/nfsd"))
 	if err != nil {
-		return Stat{}, err
+		return NFSdRPCStats{}, err
 	}
 	defer f.Close()
 
-	NFSdRPCStats := NFSdRPCStats{}
+	return parseNFSdRPCStats(f)
+}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Fields(scanner.Text())
+// parseNFSdRPCStats parses the full contents of /proc/net/rpc/nfsd from r.
+// It is split out from NewNFSdRPCStats so that NFSdStatsWatcher can reuse it
+// against a file handle it keeps open and rewinds between reads.
+func parseNFSdRPCStats(r io.Reader) (NFSdRPCStats, error) {
+	stats := NFSdRPCStats{}
+
+	fr := util.NewFieldReader(r)
+	for fr.Scan() {
+		parts := fr.Fields()
 		// require at least <key> <value>
 		if len(parts) < 2 {
-			return nil, fmt.Errorf("invalid NFSd metric line %q", line)
+			return NFSdRPCStats{}, fmt.Errorf("invalid NFSd metric line %q", fr.Line())
 		}
-		switch metricLine := parts[0]; metricLine {
-		case "rc":
-			replyCache, err := parseNFSdReplyCache(parts[1:])
+
+		metricLine := string(parts[0])
+
+		// Unlike every other line, "th" carries floating-point fields, so
+		// it can't go through the uint64 fast path below.
+		if metricLine == "th" {
+			th, err := parseNFSdThreads(parts[1:])
 			if err != nil {
-				return nil, fmt.Errorf("error parsing NFSdReplyCache: %s", err)
+				return NFSdRPCStats{}, fmt.Errorf("error parsing NFSd metric line %q: %w", fr.Line(), err)
 			}
+			stats.NFSdThreads = th
+			continue
+		}
+
+		values, err := util.ParseUint64sBytes(parts[1:])
+		if err != nil {
+			return NFSdRPCStats{}, fmt.Errorf("error parsing NFSd metric line %q: %w", fr.Line(), err)
+		}
+
+		switch metricLine {
+		case "rc":
+			stats.NFSdReplyCache, err = parseNFSdReplyCache(values)
 		case "fh":
+			stats.NFSdFileHandles, err = parseNFSdFileHandles(values)
 		case "io":
-		case "th":
+			stats.NFSdInputOutput, err = parseNFSdInputOutput(values)
 		case "ra":
+			stats.NFSdReadAheadCache, err = parseNFSdReadAheadCache(values)
+		case "net":
+			stats.NFSdNetwork, err = parseNFSdNetwork(values)
 		case "rpc":
+			stats.NFSdRPC, err = parseNFSdRPC(values)
 		case "proc2":
+			stats.NFSdv2Stats, err = parseNFSdv2Stats(values)
 		case "proc3":
+			stats.NFSdv3Stats, err = parseNFSdv3Stats(values)
 		case "proc4":
+			stats.NFSdv4Stats, err = parseNFSdv4Stats(values)
 		case "proc4ops":
+			stats.NFSdv4Ops, err = parseNFSdv4Ops(values)
 		default:
-			return nil, fmt.Errorf("invalid NFSd metric line %q", metricLine)
+			err = fmt.Errorf("invalid NFSd metric line %q", metricLine)
+		}
+		if err != nil {
+			return NFSdRPCStats{}, fmt.Errorf("error parsing NFSd metric line %q: %w", fr.Line(), err)
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return Stat{}, fmt.Errorf("couldn't parse %s: %s", f.Name(), err)
+	if err := fr.Err(); err != nil {
+		return NFSdRPCStats{}, fmt.Errorf("couldn't parse /proc/net/rpc/nfsd: %w", err)
 	}
 
-	return NFSdRPCStats, nil
+	return stats, nil
 }
@@ -0,0 +1,117 @@
+package procfs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseNFSdReadAheadCache(t *testing.T) {
+	v := []uint64{32, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	got, err := parseNFSdReadAheadCache(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := NFSdReadAheadCache{
+		CacheSize:      32,
+		CacheHistogram: [10]uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		NotFound:       11,
+	}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNFSdv4OpsVariableLength(t *testing.T) {
+	for _, n := range []int{38, 58, 71} {
+		v := make([]uint64, n+1)
+		v[0] = uint64(n)
+		for i := 1; i < len(v); i++ {
+			v[i] = uint64(i)
+		}
+
+		ops, err := parseNFSdv4Ops(v)
+		if err != nil {
+			t.Fatalf("n=%d: unexpected error: %v", n, err)
+		}
+		if ops.Values != uint64(n) {
+			t.Fatalf("n=%d: Values = %d, want %d", n, ops.Values, n)
+		}
+		if ops.Access != 4 { // 0-indexed op3 in the fixed field list
+			t.Fatalf("n=%d: Access = %d, want 4", n, ops.Access)
+		}
+
+		wantExtra := n - len(nfsdv4OpFields(&NFSdv4Ops{}))
+		if wantExtra < 0 {
+			wantExtra = 0
+		}
+		if len(ops.Extra) != wantExtra {
+			t.Fatalf("n=%d: len(Extra) = %d, want %d", n, len(ops.Extra), wantExtra)
+		}
+	}
+}
+
+// TestParseNFSdThreads uses a real "th" line (confirmed against the kernel's
+// /proc/net/rpc/nfsd output): 2 int counters followed by a 10-bucket
+// floating-point utilization histogram.
+func TestParseNFSdThreads(t *testing.T) {
+	fields := bytesFields("8 0 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000")
+	got, err := parseNFSdThreads(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Threads != 8 || got.FullCnt != 0 {
+		t.Fatalf("unexpected NFSdThreads: %+v", got)
+	}
+	if got.ThreadsHistogram != ([10]float64{}) {
+		t.Fatalf("unexpected ThreadsHistogram: %+v", got.ThreadsHistogram)
+	}
+}
+
+func bytesFields(s string) [][]byte {
+	fields := strings.Fields(s)
+	out := make([][]byte, len(fields))
+	for i, f := range fields {
+		out[i] = []byte(f)
+	}
+	return out
+}
+
+func TestParseNFSdv4OpsLengthMismatch(t *testing.T) {
+	if _, err := parseNFSdv4Ops([]uint64{3, 1, 2}); err == nil {
+		t.Fatal("expected error for header/value count mismatch, got nil")
+	}
+}
+
+func TestParseNFSdRPCStats(t *testing.T) {
+	const data = `rc 1 2 3
+fh 1 2 3 4 5
+io 10 20
+th 8 0 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000
+ra 32 1 2 3 4 5 6 7 8 9 10 11
+net 100 1 99 2
+rpc 100 0 0 0 0
+proc2 18 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+proc3 22 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+proc4 2 0 0
+proc4ops 2 1 2
+`
+	stats, err := parseNFSdRPCStats(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.NFSdReplyCache != (NFSdReplyCache{Hits: 1, Misses: 2, NoCache: 3}) {
+		t.Fatalf("unexpected NFSdReplyCache: %+v", stats.NFSdReplyCache)
+	}
+	if stats.NFSdRPC.RPCCount != 100 {
+		t.Fatalf("RPCCount = %d, want 100", stats.NFSdRPC.RPCCount)
+	}
+	if stats.NFSdThreads.Threads != 8 {
+		t.Fatalf("Threads = %d, want 8", stats.NFSdThreads.Threads)
+	}
+}
+
+func TestParseNFSdRPCStatsUnknownLine(t *testing.T) {
+	if _, err := parseNFSdRPCStats(strings.NewReader("bogus 1 2 3\n")); err == nil {
+		t.Fatal("expected error for unrecognized metric line, got nil")
+	}
+}
@@ -0,0 +1,271 @@
+package procfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// NFSdRPCStatsDelta is one reading from an NFSdStatsWatcher: the absolute
+// counters at the time of the read, and how much each counter moved since
+// the previous read.
+type NFSdRPCStatsDelta struct {
+	// Absolute is this interval's raw reading, exactly as NewNFSdRPCStats
+	// would return it.
+	Absolute NFSdRPCStats
+	// Delta is Absolute minus the previous reading, field by field, for
+	// every counter. Gauge-like fields (NFSdThreads.Threads and
+	// ThreadsHistogram, NFSdReadAheadCache.CacheSize, and each Values
+	// operation count) hold their latest value instead. On the watcher's
+	// first read there is no previous reading, so Delta equals Absolute.
+	Delta NFSdRPCStats
+	// Reset reports whether any counter in this reading was lower than in
+	// the previous one, which happens when nfsd is reloaded and its
+	// counters start over from zero. When Reset is true, the affected
+	// fields in Delta are set to their new absolute value rather than a
+	// negative (wrapped) difference.
+	Reset bool
+	// Err holds any error encountered while producing this reading. The
+	// other fields are zero value when Err is set.
+	Err error
+}
+
+// NFSdStatsWatcher watches /proc/net/rpc/nfsd for changes, keeping the file
+// handle open across reads (the kernel regenerates the content on every
+// read, so re-opening it is unnecessary) and computing the per-interval
+// delta so callers don't each have to diff snapshots themselves.
+type NFSdStatsWatcher struct {
+	file *os.File
+
+	hasPrev bool
+	prev    NFSdRPCStats
+}
+
+// NewNFSdStatsWatcher opens /proc/net/rpc/nfsd for repeated reads.
+func (fs FS) NewNFSdStatsWatcher() (*NFSdStatsWatcher, error) {
+	f, err := os.Open(fs.Path("net/rpc/nfsd"))
+	if err != nil {
+		return nil, err
+	}
+	return &NFSdStatsWatcher{file: f}, nil
+}
+
+// Close releases the underlying file handle. It does not stop a goroutine
+// started by Start: callers must cancel that call's ctx (which is what
+// actually stops the polling loop and closes its channel) before or after
+// calling Close. Calling Close first just means the next poll's Seek/Read
+// fails and the loop reports that failure via Err until ctx is done.
+func (w *NFSdStatsWatcher) Close() error {
+	return w.file.Close()
+}
+
+// Start polls /proc/net/rpc/nfsd every interval, sending one
+// NFSdRPCStatsDelta per poll on the returned channel. The channel is closed
+// once ctx is done. A read or parse error is delivered via the delta's Err
+// field rather than stopping the watcher, since a single bad read (e.g. the
+// nfsd kernel module briefly unloading) shouldn't end the stream.
+func (w *NFSdStatsWatcher) Start(ctx context.Context, interval time.Duration) <-chan NFSdRPCStatsDelta {
+	out := make(chan NFSdRPCStatsDelta)
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			delta := w.poll()
+
+			select {
+			case out <- delta:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// poll re-reads the watched file from the start and diffs it against the
+// previous reading.
+func (w *NFSdStatsWatcher) poll() NFSdRPCStatsDelta {
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return NFSdRPCStatsDelta{Err: fmt.Errorf("couldn't rewind /proc/net/rpc/nfsd: %w", err)}
+	}
+
+	cur, err := parseNFSdRPCStats(w.file)
+	if err != nil {
+		return NFSdRPCStatsDelta{Err: err}
+	}
+
+	delta := NFSdRPCStatsDelta{Absolute: cur}
+	if w.hasPrev {
+		delta.Delta, delta.Reset = diffNFSdRPCStats(w.prev, cur)
+	} else {
+		delta.Delta = cur
+	}
+	w.prev, w.hasPrev = cur, true
+
+	return delta
+}
+
+// deltaAcc accumulates per-field uint64 differences, tracking whether any
+// field went backwards (indicating the underlying counters were reset).
+type deltaAcc struct {
+	reset bool
+}
+
+// u returns cur-prev, or cur itself (and flags reset) if cur < prev.
+func (a *deltaAcc) u(prev, cur uint64) uint64 {
+	if cur < prev {
+		a.reset = true
+		return cur
+	}
+	return cur - prev
+}
+
+func diffNFSdRPCStats(prev, cur NFSdRPCStats) (NFSdRPCStats, bool) {
+	var a deltaAcc
+
+	delta := NFSdRPCStats{
+		NFSdReplyCache: NFSdReplyCache{
+			Hits:    a.u(prev.NFSdReplyCache.Hits, cur.NFSdReplyCache.Hits),
+			Misses:  a.u(prev.NFSdReplyCache.Misses, cur.NFSdReplyCache.Misses),
+			NoCache: a.u(prev.NFSdReplyCache.NoCache, cur.NFSdReplyCache.NoCache),
+		},
+		NFSdFileHandles: NFSdFileHandles{
+			Stale:        a.u(prev.NFSdFileHandles.Stale, cur.NFSdFileHandles.Stale),
+			TotalLookups: a.u(prev.NFSdFileHandles.TotalLookups, cur.NFSdFileHandles.TotalLookups),
+			AnonLookups:  a.u(prev.NFSdFileHandles.AnonLookups, cur.NFSdFileHandles.AnonLookups),
+			DirNoCache:   a.u(prev.NFSdFileHandles.DirNoCache, cur.NFSdFileHandles.DirNoCache),
+			NoDirNoCache: a.u(prev.NFSdFileHandles.NoDirNoCache, cur.NFSdFileHandles.NoDirNoCache),
+		},
+		NFSdInputOutput: NFSdInputOutput{
+			Read:  a.u(prev.NFSdInputOutput.Read, cur.NFSdInputOutput.Read),
+			Write: a.u(prev.NFSdInputOutput.Write, cur.NFSdInputOutput.Write),
+		},
+		NFSdThreads: NFSdThreads{
+			// Threads and ThreadsHistogram are gauges, not counters; report
+			// their latest values rather than a meaningless difference.
+			Threads:          cur.NFSdThreads.Threads,
+			FullCnt:          a.u(prev.NFSdThreads.FullCnt, cur.NFSdThreads.FullCnt),
+			ThreadsHistogram: cur.NFSdThreads.ThreadsHistogram,
+		},
+		NFSdNetwork: NFSdNetwork{
+			NetCount:   a.u(prev.NFSdNetwork.NetCount, cur.NFSdNetwork.NetCount),
+			UDPCount:   a.u(prev.NFSdNetwork.UDPCount, cur.NFSdNetwork.UDPCount),
+			TCPCount:   a.u(prev.NFSdNetwork.TCPCount, cur.NFSdNetwork.TCPCount),
+			TCPConnect: a.u(prev.NFSdNetwork.TCPConnect, cur.NFSdNetwork.TCPConnect),
+		},
+		NFSdRPC: NFSdRPC{
+			RPCCount: a.u(prev.NFSdRPC.RPCCount, cur.NFSdRPC.RPCCount),
+			BadCnt:   a.u(prev.NFSdRPC.BadCnt, cur.NFSdRPC.BadCnt),
+			BadFmt:   a.u(prev.NFSdRPC.BadFmt, cur.NFSdRPC.BadFmt),
+			BadAuth:  a.u(prev.NFSdRPC.BadAuth, cur.NFSdRPC.BadAuth),
+			BadcInt:  a.u(prev.NFSdRPC.BadcInt, cur.NFSdRPC.BadcInt),
+		},
+		NFSdv2Stats: NFSdv2Stats{
+			Values:   cur.NFSdv2Stats.Values,
+			Null:     a.u(prev.NFSdv2Stats.Null, cur.NFSdv2Stats.Null),
+			GetAttr:  a.u(prev.NFSdv2Stats.GetAttr, cur.NFSdv2Stats.GetAttr),
+			SetAttr:  a.u(prev.NFSdv2Stats.SetAttr, cur.NFSdv2Stats.SetAttr),
+			Root:     a.u(prev.NFSdv2Stats.Root, cur.NFSdv2Stats.Root),
+			Lookup:   a.u(prev.NFSdv2Stats.Lookup, cur.NFSdv2Stats.Lookup),
+			ReadLink: a.u(prev.NFSdv2Stats.ReadLink, cur.NFSdv2Stats.ReadLink),
+			Read:     a.u(prev.NFSdv2Stats.Read, cur.NFSdv2Stats.Read),
+			WrCache:  a.u(prev.NFSdv2Stats.WrCache, cur.NFSdv2Stats.WrCache),
+			Write:    a.u(prev.NFSdv2Stats.Write, cur.NFSdv2Stats.Write),
+			Create:   a.u(prev.NFSdv2Stats.Create, cur.NFSdv2Stats.Create),
+			Remove:   a.u(prev.NFSdv2Stats.Remove, cur.NFSdv2Stats.Remove),
+			Rename:   a.u(prev.NFSdv2Stats.Rename, cur.NFSdv2Stats.Rename),
+			Link:     a.u(prev.NFSdv2Stats.Link, cur.NFSdv2Stats.Link),
+			SymLink:  a.u(prev.NFSdv2Stats.SymLink, cur.NFSdv2Stats.SymLink),
+			MkDir:    a.u(prev.NFSdv2Stats.MkDir, cur.NFSdv2Stats.MkDir),
+			RmDir:    a.u(prev.NFSdv2Stats.RmDir, cur.NFSdv2Stats.RmDir),
+			ReadDir:  a.u(prev.NFSdv2Stats.ReadDir, cur.NFSdv2Stats.ReadDir),
+			FsStat:   a.u(prev.NFSdv2Stats.FsStat, cur.NFSdv2Stats.FsStat),
+		},
+		NFSdv3Stats: NFSdv3Stats{
+			Values:      cur.NFSdv3Stats.Values,
+			Null:        a.u(prev.NFSdv3Stats.Null, cur.NFSdv3Stats.Null),
+			GetAttr:     a.u(prev.NFSdv3Stats.GetAttr, cur.NFSdv3Stats.GetAttr),
+			SetAttr:     a.u(prev.NFSdv3Stats.SetAttr, cur.NFSdv3Stats.SetAttr),
+			Lookup:      a.u(prev.NFSdv3Stats.Lookup, cur.NFSdv3Stats.Lookup),
+			Access:      a.u(prev.NFSdv3Stats.Access, cur.NFSdv3Stats.Access),
+			ReadLink:    a.u(prev.NFSdv3Stats.ReadLink, cur.NFSdv3Stats.ReadLink),
+			Read:        a.u(prev.NFSdv3Stats.Read, cur.NFSdv3Stats.Read),
+			Write:       a.u(prev.NFSdv3Stats.Write, cur.NFSdv3Stats.Write),
+			Create:      a.u(prev.NFSdv3Stats.Create, cur.NFSdv3Stats.Create),
+			MkDir:       a.u(prev.NFSdv3Stats.MkDir, cur.NFSdv3Stats.MkDir),
+			SymLink:     a.u(prev.NFSdv3Stats.SymLink, cur.NFSdv3Stats.SymLink),
+			MkNod:       a.u(prev.NFSdv3Stats.MkNod, cur.NFSdv3Stats.MkNod),
+			Remove:      a.u(prev.NFSdv3Stats.Remove, cur.NFSdv3Stats.Remove),
+			RmDir:       a.u(prev.NFSdv3Stats.RmDir, cur.NFSdv3Stats.RmDir),
+			Rename:      a.u(prev.NFSdv3Stats.Rename, cur.NFSdv3Stats.Rename),
+			Link:        a.u(prev.NFSdv3Stats.Link, cur.NFSdv3Stats.Link),
+			ReadDir:     a.u(prev.NFSdv3Stats.ReadDir, cur.NFSdv3Stats.ReadDir),
+			ReadDirPlus: a.u(prev.NFSdv3Stats.ReadDirPlus, cur.NFSdv3Stats.ReadDirPlus),
+			FsStat:      a.u(prev.NFSdv3Stats.FsStat, cur.NFSdv3Stats.FsStat),
+			FsInfo:      a.u(prev.NFSdv3Stats.FsInfo, cur.NFSdv3Stats.FsInfo),
+			PathConf:    a.u(prev.NFSdv3Stats.PathConf, cur.NFSdv3Stats.PathConf),
+			Commit:      a.u(prev.NFSdv3Stats.Commit, cur.NFSdv3Stats.Commit),
+		},
+		NFSdv4Stats: NFSdv4Stats{
+			Values:   cur.NFSdv4Stats.Values,
+			Null:     a.u(prev.NFSdv4Stats.Null, cur.NFSdv4Stats.Null),
+			Compound: a.u(prev.NFSdv4Stats.Compound, cur.NFSdv4Stats.Compound),
+		},
+		NFSdv4Ops: diffNFSdv4Ops(&a, prev.NFSdv4Ops, cur.NFSdv4Ops),
+	}
+
+	delta.NFSdReadAheadCache = diffNFSdReadAheadCache(&a, prev.NFSdReadAheadCache, cur.NFSdReadAheadCache)
+
+	return delta, a.reset
+}
+
+func diffNFSdReadAheadCache(a *deltaAcc, prev, cur NFSdReadAheadCache) NFSdReadAheadCache {
+	d := NFSdReadAheadCache{
+		CacheSize: cur.CacheSize,
+		NotFound:  a.u(prev.NotFound, cur.NotFound),
+	}
+	for i := range d.CacheHistogram {
+		d.CacheHistogram[i] = a.u(prev.CacheHistogram[i], cur.CacheHistogram[i])
+	}
+	return d
+}
+
+// diffNFSdv4Ops diffs a proc4ops reading. If the operation count changed
+// between reads (e.g. nfsd was restarted with a different NFSv4 minor
+// version enabled), there is nothing meaningful to diff against, so the
+// current reading is returned unchanged and a reset is flagged.
+func diffNFSdv4Ops(a *deltaAcc, prev, cur NFSdv4Ops) NFSdv4Ops {
+	if prev.Values != cur.Values || len(prev.Extra) != len(cur.Extra) {
+		a.reset = true
+		return cur
+	}
+
+	d := NFSdv4Ops{Values: cur.Values}
+	curFields := nfsdv4OpFields(&cur)
+	prevFields := nfsdv4OpFields(&prev)
+	dFields := nfsdv4OpFields(&d)
+	for i := range dFields {
+		*dFields[i] = a.u(*prevFields[i], *curFields[i])
+	}
+
+	if len(cur.Extra) > 0 {
+		d.Extra = make([]uint64, len(cur.Extra))
+		for i := range cur.Extra {
+			d.Extra[i] = a.u(prev.Extra[i], cur.Extra[i])
+		}
+	}
+	return d
+}
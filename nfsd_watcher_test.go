@@ -0,0 +1,114 @@
+package procfs
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+const nfsdSampleFormat = `rc 1 2 3
+fh 1 2 3 4 5
+io 10 20
+th 8 0 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000
+ra 32 1 2 3 4 5 6 7 8 9 10 11
+net 100 1 99 2
+rpc %d 0 0 0 0
+proc2 18 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+proc3 22 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+proc4 2 0 0
+proc4ops 2 1 2
+`
+
+// writeNFSdSample rewrites f's contents in place with the given RPCCount, as
+// a re-read of /proc/net/rpc/nfsd would see after the kernel regenerates it.
+func writeNFSdSample(t *testing.T, f *os.File, rpcCount uint64) {
+	t.Helper()
+	if err := f.Truncate(0); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if _, err := fmt.Fprintf(f, nfsdSampleFormat, rpcCount); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+}
+
+func TestNFSdStatsWatcherPollDelta(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "nfsd")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	defer f.Close()
+
+	w := &NFSdStatsWatcher{file: f}
+
+	writeNFSdSample(t, f, 100)
+	first := w.poll()
+	if first.Err != nil {
+		t.Fatalf("unexpected error: %v", first.Err)
+	}
+	if first.Delta.NFSdRPC.RPCCount != first.Absolute.NFSdRPC.RPCCount {
+		t.Fatalf("first poll's Delta should equal Absolute, got %+v", first.Delta.NFSdRPC)
+	}
+	if first.Reset {
+		t.Fatal("first poll should not report a reset")
+	}
+
+	writeNFSdSample(t, f, 150)
+	second := w.poll()
+	if second.Err != nil {
+		t.Fatalf("unexpected error: %v", second.Err)
+	}
+	if second.Delta.NFSdRPC.RPCCount != 50 {
+		t.Fatalf("RPCCount delta = %d, want 50", second.Delta.NFSdRPC.RPCCount)
+	}
+	if second.Reset {
+		t.Fatal("second poll should not report a reset")
+	}
+}
+
+func TestNFSdStatsWatcherPollCounterReset(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "nfsd")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	defer f.Close()
+
+	w := &NFSdStatsWatcher{file: f}
+
+	writeNFSdSample(t, f, 1000)
+	if poll := w.poll(); poll.Err != nil {
+		t.Fatalf("unexpected error: %v", poll.Err)
+	}
+
+	// Simulate nfsd restarting: counters go back to a small value.
+	writeNFSdSample(t, f, 5)
+	reset := w.poll()
+	if reset.Err != nil {
+		t.Fatalf("unexpected error: %v", reset.Err)
+	}
+	if !reset.Reset {
+		t.Fatal("expected Reset to be true after a counter went backwards")
+	}
+	if reset.Delta.NFSdRPC.RPCCount != 5 {
+		t.Fatalf("RPCCount delta after reset = %d, want the new absolute value 5", reset.Delta.NFSdRPC.RPCCount)
+	}
+}
+
+func TestDiffNFSdv4OpsVersionChange(t *testing.T) {
+	var a deltaAcc
+	prev := NFSdv4Ops{Values: 38}
+	cur := NFSdv4Ops{Values: 58, Extra: make([]uint64, 20)}
+
+	got := diffNFSdv4Ops(&a, prev, cur)
+	if !a.reset {
+		t.Fatal("expected a reset when the operation count changes between reads")
+	}
+	if got.Values != cur.Values {
+		t.Fatalf("Values = %d, want %d", got.Values, cur.Values)
+	}
+}